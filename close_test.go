@@ -0,0 +1,93 @@
+package rocketzap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+type fakeTransport struct {
+	mu   sync.Mutex
+	sent int
+}
+
+func (f *fakeTransport) Send(ctx context.Context, msg *models.PostMessage) error {
+	f.mu.Lock()
+	f.sent++
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeTransport) sentCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sent
+}
+
+func TestCloseFlushesPendingBatch(t *testing.T) {
+	ft := &fakeTransport{}
+	rh := &RocketHook{Transport: ft, Duration: -1, Batch: 100}
+	if err := rh.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rh.enqueue(&models.Attachment{Title: "pending"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rh.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got := ft.sentCount(); got != 1 {
+		t.Fatalf("sent = %d, want 1", got)
+	}
+}
+
+func TestCloseIsSafeConcurrently(t *testing.T) {
+	rh := &RocketHook{Transport: &fakeTransport{}, Duration: -1, Batch: 100}
+	if err := rh.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			errs[i] = rh.Close(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Close() [%d] error = %v", i, err)
+		}
+	}
+}
+
+func TestFlushAfterCloseReturnsNil(t *testing.T) {
+	rh := &RocketHook{Transport: &fakeTransport{}, Duration: -1, Batch: 100}
+	if err := rh.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rh.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+	if err := rh.flush(ctx2); err != nil {
+		t.Fatalf("flush() after Close error = %v, want nil", err)
+	}
+}
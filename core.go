@@ -0,0 +1,73 @@
+package rocketzap
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// core is the zapcore.Core implementation backing NewCore. It carries the
+// fields accumulated through With so they can be merged with the fields
+// passed to Write before being handed to the RocketHook they wrap.
+type core struct {
+	hook   *RocketHook
+	fields []zapcore.Field
+}
+
+// NewCore wraps rh in a zapcore.Core, so structured fields attached via
+// zap.String, zap.Int, zap.Any, etc. are rendered and sent to RocketChat
+// instead of being dropped.
+func NewCore(rh *RocketHook) zapcore.Core {
+	return &core{hook: rh}
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *core) Enabled(level zapcore.Level) bool {
+	return c.hook.isAcceptedLevel(level)
+}
+
+// With returns a new core accumulating fields on top of the ones it
+// already carries.
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{
+		hook:   c.hook,
+		fields: append(appendFields(nil, c.fields), fields...),
+	}
+}
+
+// Check implements zapcore.Core.
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core, sending the rendered entry to RocketChat.
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.hook.Disabled {
+		return nil
+	}
+	if !c.hook.isRunning() {
+		return NotRunningErr
+	}
+
+	all := appendFields(nil, c.fields)
+	all = append(all, fields...)
+	c.hook.enqueue(c.hook.Formatter.Format(ent, all))
+
+	if ent.Level == zapcore.FatalLevel || ent.Level == zapcore.PanicLevel {
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync implements zapcore.Core, flushing any batch still sitting in the
+// hook's queue instead of waiting for it to fill up or the timer to fire.
+func (c *core) Sync() error {
+	return c.hook.flush(context.Background())
+}
+
+func appendFields(dst, src []zapcore.Field) []zapcore.Field {
+	return append(dst, src...)
+}
@@ -0,0 +1,102 @@
+package rocketzap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxShortFieldLen is the longest rendered field value that is still shown
+// as a "short" attachment field (displayed side-by-side in RocketChat).
+const maxShortFieldLen = 40
+
+// Formatter renders a zap entry, plus any structured fields attached to
+// it, into a RocketChat attachment.
+type Formatter interface {
+	Format(e zapcore.Entry, fields []zapcore.Field) *models.Attachment
+}
+
+// DefaultFormatter is the Formatter RocketHook uses when none is set: a
+// color keyed off the log level, a "<level> log" title, and the message
+// plus caller/stack in Text, with any structured fields rendered as
+// attachment fields.
+type DefaultFormatter struct{}
+
+// Format implements Formatter.
+func (DefaultFormatter) Format(e zapcore.Entry, fields []zapcore.Field) *models.Attachment {
+	color := ""
+	switch e.Level {
+	case zapcore.DebugLevel:
+		color = "purple"
+	case zapcore.InfoLevel:
+		color = "green"
+	case zapcore.ErrorLevel, zapcore.PanicLevel, zapcore.DPanicLevel, zapcore.FatalLevel:
+		color = "red"
+	default:
+		color = "yellow"
+	}
+
+	stack := ""
+	if len(e.Stack) > 0 {
+		stack = "\n\nStack:\n" + e.Stack
+	}
+
+	attachment := &models.Attachment{
+		Color:     color,
+		Title:     e.Level.String() + " log",
+		Timestamp: e.Time.String(),
+		Text:      e.Message + "\n\nCaller:\n" + e.Caller.String() + stack,
+	}
+	if len(fields) > 0 {
+		attachment.Fields = formatFields(fields)
+	}
+	return attachment
+}
+
+// formatFields renders zap fields as RocketChat attachment fields, one per
+// key each zap.Field encodes (usually one per zap.Field), in the order the
+// fields were supplied; the keys of a field that encodes several (e.g.
+// zap.Inline) come out in no defined order. Values longer than
+// maxShortFieldLen are rendered as long (full width) fields; everything
+// else is short, so plain key/value pairs sit side by side.
+func formatFields(fields []zapcore.Field) []models.AttachmentField {
+	result := make([]models.AttachmentField, 0, len(fields))
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for key, val := range enc.Fields {
+			value := formatFieldValue(val)
+			result = append(result, models.AttachmentField{
+				Title: key,
+				Value: value,
+				Short: len(value) <= maxShortFieldLen,
+			})
+		}
+	}
+	return result
+}
+
+// formatFieldValue stringifies an encoded zap field value, falling back to
+// JSON for maps, slices and structs so complex types stay readable.
+func formatFieldValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return fmt.Sprintf("%v", v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,24 @@
+package rocketzap
+
+// OverflowPolicy decides what RocketHook does with a new attachment when
+// its bounded queue (see RocketHook.QueueSize) is already full.
+type OverflowPolicy int
+
+const (
+	// Block makes the caller wait until room frees up. This preserves
+	// every log line but can stall the calling goroutine.
+	Block OverflowPolicy = iota
+	// DropOldest discards the longest-queued attachment to make room for
+	// the new one.
+	DropOldest
+	// DropNewest discards the incoming attachment, leaving the queue
+	// untouched.
+	DropNewest
+)
+
+// Stats reports RocketHook delivery health.
+type Stats struct {
+	// Dropped is the number of attachments discarded by OverflowPolicy
+	// because the queue was full.
+	Dropped uint64
+}
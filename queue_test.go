@@ -0,0 +1,37 @@
+package rocketzap
+
+import (
+	"testing"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+func TestEnqueueDropNewest(t *testing.T) {
+	rh := &RocketHook{OverflowPolicy: DropNewest}
+	rh.msgChan = make(chan *models.Attachment, 1)
+
+	rh.enqueue(&models.Attachment{Title: "first"})
+	rh.enqueue(&models.Attachment{Title: "second"})
+
+	if got := rh.Stats().Dropped; got != 1 {
+		t.Fatalf("Stats().Dropped = %d, want 1", got)
+	}
+	if kept := <-rh.msgChan; kept.Title != "first" {
+		t.Fatalf("queue kept %q, want %q", kept.Title, "first")
+	}
+}
+
+func TestEnqueueDropOldest(t *testing.T) {
+	rh := &RocketHook{OverflowPolicy: DropOldest}
+	rh.msgChan = make(chan *models.Attachment, 1)
+
+	rh.enqueue(&models.Attachment{Title: "first"})
+	rh.enqueue(&models.Attachment{Title: "second"})
+
+	if got := rh.Stats().Dropped; got != 1 {
+		t.Fatalf("Stats().Dropped = %d, want 1", got)
+	}
+	if kept := <-rh.msgChan; kept.Title != "second" {
+		t.Fatalf("queue kept %q, want %q", kept.Title, "second")
+	}
+}
@@ -0,0 +1,222 @@
+package rocketzap
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// routePostMessage identifies the chat.postMessage endpoint bucket. It is
+// the only route the limiter is used for; file uploads (uploadFile) don't
+// go through it.
+const routePostMessage = "chat.postMessage"
+
+// RateLimit configures how hard RocketHook is allowed to hit RocketChat's
+// REST API. RocketChat enforces per-route limits (10 requests/minute by
+// default for many routes), and a hook running several batches across
+// multiple instances can easily burst through that.
+type RateLimit struct {
+	// Requests is how many requests are allowed per Interval.
+	// Default is 10.
+	Requests int
+	// Interval is the window Requests applies to. Default is time.Minute.
+	Interval time.Duration
+	// Burst allows this many requests to fire back-to-back before the
+	// steady rate kicks in. Default is Requests.
+	Burst int
+}
+
+// rateLimiter is a global limiter plus per-route buckets, each tracking
+// remaining/limit/resetAt the way RocketChat's rate-limit headers report
+// them, so the sender goroutine blocks in front of PostMessage rather than
+// sleeping ad-hoc.
+type rateLimiter struct {
+	cfg    RateLimit
+	global *bucket
+
+	mu     sync.Mutex
+	routes map[string]*bucket
+}
+
+func newRateLimiter(cfg RateLimit) *rateLimiter {
+	if cfg.Requests <= 0 {
+		cfg.Requests = 10
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Requests
+	}
+	return &rateLimiter{
+		cfg:    cfg,
+		global: newBucket(cfg),
+		routes: make(map[string]*bucket),
+	}
+}
+
+func (rl *rateLimiter) bucket(route string) *bucket {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, ok := rl.routes[route]
+	if !ok {
+		b = newBucket(rl.cfg)
+		rl.routes[route] = b
+	}
+	return b
+}
+
+// Wait blocks until both the global bucket and route's own bucket have a
+// token available.
+func (rl *rateLimiter) Wait(route string) {
+	rl.global.wait()
+	rl.bucket(route).wait()
+}
+
+// Suspend parks route's bucket for d, as directed by a 429's Retry-After.
+func (rl *rateLimiter) Suspend(route string, d time.Duration) {
+	rl.bucket(route).suspendFor(d)
+}
+
+// UpdateFromHeader refreshes route's bucket from a RocketChat response's
+// X-RateLimit-* headers.
+func (rl *rateLimiter) UpdateFromHeader(route string, header http.Header) {
+	rl.bucket(route).updateFromHeader(header)
+}
+
+// bucket is a simple token bucket: remaining/limit/resetAt mirror what
+// RocketChat reports in its rate-limit headers, refilled at a steady rate
+// between updates so we keep pacing requests even without fresh headers.
+type bucket struct {
+	mu sync.Mutex
+
+	limit     int
+	remaining int
+	resetAt   time.Time
+
+	tokens         float64
+	refillPerSec   float64
+	lastRefill     time.Time
+	suspendedUntil time.Time
+}
+
+func newBucket(cfg RateLimit) *bucket {
+	return &bucket{
+		limit:        cfg.Requests,
+		remaining:    cfg.Burst,
+		tokens:       float64(cfg.Burst),
+		refillPerSec: float64(cfg.Requests) / cfg.Interval.Seconds(),
+		lastRefill:   time.Now(),
+	}
+}
+
+func (b *bucket) wait() {
+	for {
+		b.mu.Lock()
+		if !b.suspendedUntil.IsZero() {
+			if wait := time.Until(b.suspendedUntil); wait > 0 {
+				b.mu.Unlock()
+				time.Sleep(wait)
+				continue
+			}
+			b.suspendedUntil = time.Time{}
+		}
+
+		b.refill()
+		if b.tokens >= 1 {
+			b.tokens--
+			b.remaining = int(b.tokens)
+			b.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - b.tokens) / b.refillPerSec * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// refill must be called with b.mu held.
+func (b *bucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.refillPerSec
+	if max := float64(b.limit); b.tokens > max {
+		b.tokens = max
+	}
+	b.lastRefill = now
+}
+
+func (b *bucket) suspendFor(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.suspendedUntil = time.Now().Add(d)
+	b.mu.Unlock()
+}
+
+func (b *bucket) updateFromHeader(header http.Header) {
+	if header == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v := header.Get("X-RateLimit-Limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			b.limit = n
+		}
+	}
+	if v := header.Get("X-RateLimit-Remaining"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			b.remaining = n
+			b.tokens = float64(n)
+		}
+	}
+	if v := header.Get("X-RateLimit-Reset"); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			b.resetAt = time.Unix(0, ms*int64(time.Millisecond))
+		}
+	}
+}
+
+// parseRetryAfter reads a Retry-After header value, which RocketChat sends
+// as a number of seconds.
+func parseRetryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// httpStatusError is implemented by transport errors that carry a response
+// status and headers (webhookError, restError), so applyRateLimitFeedback
+// reacts to both the same way regardless of which Transport produced the
+// error.
+type httpStatusError interface {
+	error
+	httpStatusCode() int
+	httpHeader() http.Header
+}
+
+// applyRateLimitFeedback folds a transport error's rate-limit signal (a
+// 429 with Retry-After, or fresh X-RateLimit-* headers) back into the
+// limiter so the next Wait call paces correctly.
+func applyRateLimitFeedback(rl *rateLimiter, route string, err error) {
+	herr, ok := err.(httpStatusError)
+	if !ok || herr.httpHeader() == nil {
+		return
+	}
+	header := herr.httpHeader()
+	rl.UpdateFromHeader(route, header)
+	if herr.httpStatusCode() == http.StatusTooManyRequests {
+		rl.Suspend(route, parseRetryAfter(header))
+	}
+}
@@ -0,0 +1,114 @@
+package rocketzap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+func TestBucketSuspendDelaysWait(t *testing.T) {
+	// A fast refill rate keeps this test's runtime close to the 50ms
+	// suspend window rather than the minutes a slow-refill config would
+	// otherwise add on top of it.
+	b := newBucket(RateLimit{Requests: 1000, Interval: time.Second, Burst: 1})
+	b.wait()
+
+	b.suspendFor(50 * time.Millisecond)
+	start := time.Now()
+	b.wait()
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("wait returned before the suspend window elapsed")
+	}
+}
+
+func TestBucketWaitSleepIsProportionalToTokensRemaining(t *testing.T) {
+	// 10 requests/minute is 6s/token. Starting one token shy of ready
+	// should cost ~60ms, not a full 6s refill period.
+	b := newBucket(RateLimit{Requests: 10, Interval: time.Minute, Burst: 10})
+	b.mu.Lock()
+	b.tokens = 0.99
+	b.lastRefill = time.Now()
+	b.mu.Unlock()
+
+	start := time.Now()
+	b.wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("wait() took %v for tokens=0.99, want well under a full 6s refill period", elapsed)
+	}
+}
+
+func TestApplyRateLimitFeedbackSuspendsOn429(t *testing.T) {
+	rl := newRateLimiter(RateLimit{Requests: 10, Interval: time.Minute})
+	header := http.Header{}
+	header.Set("Retry-After", "1")
+	err := &webhookError{StatusCode: http.StatusTooManyRequests, Header: header}
+
+	applyRateLimitFeedback(rl, routePostMessage, err)
+
+	if rl.bucket(routePostMessage).suspendedUntil.IsZero() {
+		t.Fatal("expected the route's bucket to be suspended after a 429")
+	}
+}
+
+// TestApplyRateLimitFeedbackSuspendsOn429 covers webhookError; this checks
+// that a restError with the same status and headers gets the same treatment.
+func TestApplyRateLimitFeedbackHandlesRESTAndWebhookErrorsAlike(t *testing.T) {
+	rl := newRateLimiter(RateLimit{Requests: 10, Interval: time.Minute})
+	header := http.Header{}
+	header.Set("Retry-After", "1")
+	err := &restError{StatusCode: http.StatusTooManyRequests, Header: header}
+
+	applyRateLimitFeedback(rl, routePostMessage, err)
+
+	if rl.bucket(routePostMessage).suspendedUntil.IsZero() {
+		t.Fatal("expected the route's bucket to be suspended after a 429 from RESTTransport")
+	}
+}
+
+func TestApplyRateLimitFeedbackIgnoresPlainErrors(t *testing.T) {
+	rl := newRateLimiter(RateLimit{Requests: 10, Interval: time.Minute})
+	applyRateLimitFeedback(rl, routePostMessage, errors.New("boom"))
+
+	if !rl.bucket(routePostMessage).suspendedUntil.IsZero() {
+		t.Fatal("expected no suspension from an error that isn't an httpStatusError")
+	}
+}
+
+// headeringTransport is a Transport that also implements RateLimitHeaderer,
+// the way RESTTransport and WebhookTransport do.
+type headeringTransport struct {
+	header http.Header
+}
+
+func (h *headeringTransport) Send(ctx context.Context, msg *models.PostMessage) error {
+	return nil
+}
+
+func (h *headeringTransport) RateLimitHeader() http.Header {
+	return h.header
+}
+
+func TestSend1UpdatesLimiterFromSuccessHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "3")
+	rh := &RocketHook{
+		Transport: &headeringTransport{header: header},
+		limiter:   newRateLimiter(RateLimit{Requests: 10, Interval: time.Minute}),
+	}
+
+	if err := rh.send1(context.Background()); err != nil {
+		t.Fatalf("send1() error = %v", err)
+	}
+
+	b := rh.limiter.bucket(routePostMessage)
+	b.mu.Lock()
+	remaining := b.remaining
+	b.mu.Unlock()
+	if remaining != 3 {
+		t.Fatalf("bucket.remaining = %d, want 3 after a successful response carrying X-RateLimit-Remaining", remaining)
+	}
+}
@@ -0,0 +1,20 @@
+package rocketzap
+
+import "github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+
+// Reporter observes batch delivery outcomes, e.g. to export metrics or
+// alert on sustained failures. RocketHook calls it after every attempted
+// batch, whether or not it was delivered.
+type Reporter interface {
+	OnSuccess(batch []models.Attachment)
+	OnError(batch []models.Attachment, err error)
+}
+
+// NoopReporter is the Reporter RocketHook uses when none is set.
+type NoopReporter struct{}
+
+// OnSuccess implements Reporter.
+func (NoopReporter) OnSuccess(batch []models.Attachment) {}
+
+// OnError implements Reporter.
+func (NoopReporter) OnError(batch []models.Attachment, err error) {}
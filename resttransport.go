@@ -0,0 +1,149 @@
+package rocketzap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/rest"
+)
+
+// RESTTransport delivers messages by logging in as a bot user via the
+// RocketChat REST API and calling chat.postMessage. It is RocketHook's
+// default Transport whenever HookURL doesn't look like an Incoming
+// Webhook URL.
+type RESTTransport struct {
+	HookURL string
+	Channel string
+	// If UserID and Token are present, will use UserID and Token auth rocket.chat API
+	// otherwise Email and the Password are mandatory.
+	UserID   string
+	Token    string
+	Email    string
+	Password string
+
+	client      *rest.Client
+	credentials *models.UserCredentials
+	baseURL     string
+	httpClient  *http.Client
+	lastHeader  http.Header
+}
+
+// Send implements Transport, logging in lazily on first use. It POSTs to
+// chat.postMessage directly rather than going through rest.Client, which
+// reads the response body but discards its status code and headers - so a
+// 429 or fresh X-RateLimit-* headers never reach applyRateLimitFeedback.
+// Posting it ourselves, the way WebhookTransport and uploadFile already
+// do, keeps rate-limit feedback working for the default (REST) transport
+// too, not just ModeWebhook.
+func (t *RESTTransport) Send(ctx context.Context, msg *models.PostMessage) error {
+	if t.client == nil {
+		if err := t.Reconnect(); err != nil {
+			return err
+		}
+	}
+	if t.httpClient == nil {
+		t.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/api/v1/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Auth-Token", t.credentials.Token)
+	req.Header.Set("X-User-Id", t.credentials.ID)
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	t.lastHeader = resp.Header
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &restError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+
+	var parsed struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err == nil && !parsed.Success && parsed.Error != "" {
+		return fmt.Errorf("rocketzap: %s", parsed.Error)
+	}
+	return nil
+}
+
+// RateLimitHeader implements RateLimitHeaderer, exposing the X-RateLimit-*
+// headers from the most recent chat.postMessage response, whether or not
+// it succeeded, so the limiter can update proactively rather than only
+// reacting to a 429.
+func (t *RESTTransport) RateLimitHeader() http.Header {
+	return t.lastHeader
+}
+
+// restError reports a non-2xx response from RocketChat's REST API,
+// mirroring webhookError so applyRateLimitFeedback can read rate-limit
+// and Retry-After headers off it regardless of which Transport produced
+// it.
+type restError struct {
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *restError) Error() string {
+	return fmt.Sprintf("rocketzap: chat.postMessage failed with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *restError) httpStatusCode() int     { return e.StatusCode }
+func (e *restError) httpHeader() http.Header { return e.Header }
+
+// Reconnect implements Reconnector, rebuilding the rest.Client and logging
+// in again from scratch. It's used both to recover from an expired
+// session and, via ZombieThreshold, to recover from a connection that
+// looks wedged.
+func (t *RESTTransport) Reconnect() error {
+	index := strings.Index(t.HookURL, "://")
+	serverUrl := &url.URL{
+		Scheme: "http",
+	}
+	if index > 0 {
+		serverUrl.Host = t.HookURL[index+len("://"):]
+		if strings.HasPrefix(t.HookURL, "https") {
+			serverUrl.Scheme = "https"
+		}
+	} else {
+		serverUrl.Host = t.HookURL
+	}
+
+	t.baseURL = serverUrl.String()
+	t.client = rest.NewClient(serverUrl, false)
+	t.credentials = &models.UserCredentials{
+		ID:       t.UserID,
+		Token:    t.Token,
+		Email:    t.Email,
+		Password: t.Password,
+	}
+	return t.client.Login(t.credentials)
+}
+
+// UploadFile implements Uploader, uploading content to Channel via
+// RocketChat's room-upload endpoint and returning a link to it.
+func (t *RESTTransport) UploadFile(filename, content string) (string, error) {
+	if t.baseURL == "" || t.credentials == nil {
+		return "", errNotConnected
+	}
+	return uploadFile(t.baseURL, t.Channel, t.credentials.ID, t.credentials.Token, filename, content)
+}
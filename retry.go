@@ -0,0 +1,28 @@
+package rocketzap
+
+import "strings"
+
+// authExpiredMarkers are substrings RocketChat's REST API is known to
+// return in error bodies when the session token has expired or was
+// rejected, as opposed to a transient network or server error.
+var authExpiredMarkers = []string{
+	"401",
+	"unauthorized",
+	"you must be logged in",
+	"invalid or expired",
+}
+
+// isAuthExpired reports whether err looks like an authentication failure
+// that a fresh Login would fix, rather than a transient delivery error.
+func isAuthExpired(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range authExpiredMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package rocketzap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsAuthExpired(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("401 Unauthorized"), true},
+		{errors.New("You must be logged in to do this"), true},
+		{errors.New("invalid or expired auth token"), true},
+		{errors.New("connection refused"), false},
+	}
+	for _, c := range cases {
+		if got := isAuthExpired(c.err); got != c.want {
+			t.Errorf("isAuthExpired(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestRocketHookBackoff(t *testing.T) {
+	rh := &RocketHook{RetryBaseDelay: 100 * time.Millisecond, RetryMaxDelay: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := rh.backoff(attempt)
+		if d <= 0 || d > rh.RetryMaxDelay {
+			t.Errorf("backoff(%d) = %v, want in (0, %v]", attempt, d, rh.RetryMaxDelay)
+		}
+	}
+}
@@ -2,13 +2,15 @@
 package rocketzap
 
 import (
+	"context"
 	"fmt"
-	"net/url"
+	"math/rand"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
-	"github.com/RocketChat/Rocket.Chat.Go.SDK/rest"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -28,7 +30,11 @@ var AllLevels = []zapcore.Level{
 }
 
 // RocketHook is a logrus Hook for dispatching messages to the specified
-// channel on RocketChat.
+// channel on RocketChat. It is a thin orchestrator: Formatter renders
+// entries, Transport delivers them, and Reporter observes the outcome.
+// Set them directly for custom behavior; otherwise RocketHook builds
+// sensible defaults (DefaultFormatter, RESTTransport or WebhookTransport,
+// NoopReporter) from the fields below.
 type RocketHook struct {
 	HookURL string
 	Channel string
@@ -39,6 +45,22 @@ type RocketHook struct {
 	Email    string
 	Password string
 
+	// Mode selects the default Transport. ModeAuto (the default) picks
+	// ModeWebhook when HookURL looks like a RocketChat Incoming Webhook
+	// URL (i.e. it contains "/hooks/"), otherwise ModeREST. Ignored if
+	// Transport is set explicitly.
+	Mode Mode
+
+	// Formatter renders entries into attachments. Default is
+	// DefaultFormatter.
+	Formatter Formatter
+	// Transport delivers batched messages. Default is built from
+	// HookURL/Mode/UserID/Token/Email/Password/Channel: a RESTTransport
+	// or a WebhookTransport.
+	Transport Transport
+	// Reporter observes batch delivery outcomes. Default is NoopReporter.
+	Reporter Reporter
+
 	// Messages with a log level not contained in this array
 	// will not be dispatched. If nil, all messages will be dispatched.
 	AcceptedLevels []zapcore.Level
@@ -58,12 +80,57 @@ type RocketHook struct {
 	// batch send message, default is 8
 	Batch int
 
-	running bool
-	msg     *models.PostMessage
-	msgChan chan *models.Attachment
+	// QueueSize bounds how many pending attachments may sit in msgChan
+	// before OverflowPolicy kicks in. Default is 16.
+	QueueSize int
+	// OverflowPolicy decides what happens when the queue is full.
+	// Default is Block.
+	OverflowPolicy OverflowPolicy
+
+	// MaxRetries is how many extra attempts postMessage makes after an
+	// initial failed send, before giving up on that batch. Default is 3.
+	MaxRetries int
+	// RetryBaseDelay is the initial backoff between retries, doubled on
+	// each subsequent attempt up to RetryMaxDelay, then jittered.
+	// Default is 500ms.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the backoff delay. Default is 30s.
+	RetryMaxDelay time.Duration
+	// ZombieThreshold is the number of consecutive failed batches that
+	// marks the connection as zombied, triggering a Transport.Reconnect
+	// (if implemented). Zero disables zombie detection.
+	ZombieThreshold int
+
+	// RateLimit paces outbound requests so a small Batch/Duration, or
+	// several hook instances, don't burst through RocketChat's REST
+	// limits. Default is 10 requests/minute.
+	RateLimit RateLimit
+
+	// MaxInlineBytes caps how large a single attachment's Text, or the
+	// running total of the batch's attachment Text, may be before
+	// postMessage uploads the attachment as a file instead and replaces
+	// it with a short summary plus a link. Default is 5000 bytes. Only
+	// takes effect if Transport implements Uploader.
+	MaxInlineBytes int64
+
+	running             int32 // atomic; 1 once Run has started, 0 after Close completes
+	closeOnce           sync.Once
+	consecutiveFailures int
+	dropped             uint64
+	limiter             *rateLimiter
+	msg                 *models.PostMessage
+	msgChan             chan *models.Attachment
+	flushChan           chan chan error
+	stopChan            chan struct{}
+	stopped             chan struct{}
+}
 
-	*models.UserCredentials
-	*rest.Client
+// isRunning reports whether Run has started and Close hasn't finished yet.
+// running is read and written from multiple goroutines (Run, Close, the
+// zapcore.Core/GetHook callers logging concurrently), so it's accessed
+// atomically rather than as a plain bool.
+func (rh *RocketHook) isRunning() bool {
+	return atomic.LoadInt32(&rh.running) == 1
 }
 
 // Levels sets which levels to sent to RocketChat
@@ -90,30 +157,45 @@ func LevelThreshold(l zapcore.Level) []zapcore.Level {
 
 // Run start RocketHook message processor
 func (rh *RocketHook) Run() error {
-	index := strings.Index(rh.HookURL, "://")
-	serverUrl := &url.URL{
-		Scheme: "http",
+	if rh.Formatter == nil {
+		rh.Formatter = DefaultFormatter{}
+	}
+	if rh.Reporter == nil {
+		rh.Reporter = NoopReporter{}
 	}
-	if index > 0 {
-		serverUrl.Host = rh.HookURL[index+len("://"):]
-		if strings.HasPrefix(rh.HookURL, "https") {
-			serverUrl.Scheme = "https"
+	if rh.Transport == nil {
+		mode := rh.Mode
+		if mode == ModeAuto {
+			mode = detectMode(rh.HookURL)
+		}
+		if mode == ModeWebhook {
+			rh.Transport = &WebhookTransport{HookURL: rh.HookURL}
+		} else {
+			rh.Transport = &RESTTransport{
+				HookURL:  rh.HookURL,
+				Channel:  rh.Channel,
+				UserID:   rh.UserID,
+				Token:    rh.Token,
+				Email:    rh.Email,
+				Password: rh.Password,
+			}
 		}
-	} else {
-		serverUrl.Host = rh.HookURL
 	}
-
-	rh.Client = rest.NewClient(serverUrl, false)
-	rh.UserCredentials = &models.UserCredentials{
-		ID:       rh.UserID,
-		Token:    rh.Token,
-		Email:    rh.Email,
-		Password: rh.Password,
+	if reconnector, ok := rh.Transport.(Reconnector); ok {
+		if err := reconnector.Reconnect(); err != nil {
+			return err
+		}
 	}
-	if err := rh.Client.Login(rh.UserCredentials); err != nil {
-		return err
+	rh.limiter = newRateLimiter(rh.RateLimit)
+
+	queueSize := rh.QueueSize
+	if queueSize <= 0 {
+		queueSize = 16
 	}
-	rh.msgChan = make(chan *models.Attachment, 16)
+	rh.msgChan = make(chan *models.Attachment, queueSize)
+	rh.flushChan = make(chan chan error)
+	rh.stopChan = make(chan struct{})
+	rh.stopped = make(chan struct{})
 	if rh.Duration == 0 {
 		rh.Duration = 10
 	}
@@ -136,7 +218,7 @@ func (rh *RocketHook) Run() error {
 	}
 
 	go rh.send()
-	rh.running = true
+	atomic.StoreInt32(&rh.running, 1)
 	return nil
 }
 
@@ -172,16 +254,217 @@ func (rh *RocketHook) send() {
 
 			rh.postMessage()
 			timer.Reset(duration)
+		case reply := <-rh.flushChan:
+			rh.drainQueued()
+			var err error
+			if len(rh.msg.Attachments) > 0 {
+				err = rh.postMessage()
+			}
+			reply <- err
+		case <-rh.stopChan:
+			close(rh.stopped)
+			return
+		}
+	}
+}
+
+// drainQueued pulls every attachment currently sitting in msgChan into the
+// pending batch without blocking, so a flush picks up everything that was
+// enqueued before it was requested.
+func (rh *RocketHook) drainQueued() {
+	for {
+		select {
+		case msg := <-rh.msgChan:
+			rh.msg.Attachments = append(rh.msg.Attachments, *msg)
+		default:
+			return
+		}
+	}
+}
+
+// send1 performs a single Transport.Send attempt, blocking on the rate
+// limiter first and feeding its response back in.
+func (rh *RocketHook) send1(ctx context.Context) error {
+	rh.limiter.Wait(routePostMessage)
+	err := rh.Transport.Send(ctx, rh.msg)
+	applyRateLimitFeedback(rh.limiter, routePostMessage, err)
+	if headerer, ok := rh.Transport.(RateLimitHeaderer); ok {
+		if header := headerer.RateLimitHeader(); header != nil {
+			rh.limiter.UpdateFromHeader(routePostMessage, header)
 		}
 	}
+	return err
 }
 
-func (rh *RocketHook) postMessage() {
-	rh.Client.PostMessage(rh.msg)
+// postMessage sends the pending batch, retrying with backoff, asking
+// Transport to reconnect on auth-expired errors, and rebuilding the
+// connection once ZombieThreshold consecutive batches have failed
+// outright, then reports the outcome to Reporter.
+func (rh *RocketHook) postMessage() error {
+	rh.offloadOversizeAttachments()
+
+	batch := make([]models.Attachment, len(rh.msg.Attachments))
+	copy(batch, rh.msg.Attachments)
+
+	err := rh.postWithRetry()
+	if err != nil {
+		rh.consecutiveFailures++
+		if rh.ZombieThreshold > 0 && rh.consecutiveFailures >= rh.ZombieThreshold {
+			if reconnector, ok := rh.Transport.(Reconnector); ok {
+				reconnector.Reconnect()
+			}
+			rh.consecutiveFailures = 0
+		}
+		rh.Reporter.OnError(batch, err)
+	} else {
+		rh.consecutiveFailures = 0
+		rh.Reporter.OnSuccess(batch)
+	}
+
 	rh.msg.Attachments = rh.msg.Attachments[:0]
 	if cap(rh.msg.Attachments) > 1024 {
 		rh.msg.Attachments = make([]models.Attachment, 0, 16)
 	}
+	return err
+}
+
+// postWithRetry retries send1 with exponential backoff and jitter, asking
+// Transport to reconnect first whenever a failure looks auth-expired.
+func (rh *RocketHook) postWithRetry() error {
+	maxRetries := rh.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = rh.send1(context.Background())
+		if err == nil {
+			return nil
+		}
+		if isAuthExpired(err) {
+			if reconnector, ok := rh.Transport.(Reconnector); ok {
+				if reconnector.Reconnect() == nil {
+					continue
+				}
+			}
+		}
+		if attempt == maxRetries {
+			break
+		}
+		time.Sleep(rh.backoff(attempt))
+	}
+	return err
+}
+
+// backoff returns the exponential-with-jitter delay for a given (0-based)
+// retry attempt.
+func (rh *RocketHook) backoff(attempt int) time.Duration {
+	base := rh.RetryBaseDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	maxDelay := rh.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// enqueue hands msg off to the sender goroutine via msgChan, applying
+// OverflowPolicy when the queue is full.
+func (rh *RocketHook) enqueue(msg *models.Attachment) {
+	switch rh.OverflowPolicy {
+	case DropNewest:
+		select {
+		case rh.msgChan <- msg:
+		default:
+			atomic.AddUint64(&rh.dropped, 1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case rh.msgChan <- msg:
+				return
+			default:
+				select {
+				case <-rh.msgChan:
+					atomic.AddUint64(&rh.dropped, 1)
+				default:
+				}
+			}
+		}
+	default: // Block
+		rh.msgChan <- msg
+	}
+}
+
+// Stats reports delivery health, currently the number of attachments
+// dropped by OverflowPolicy since Run was called.
+func (rh *RocketHook) Stats() Stats {
+	return Stats{Dropped: atomic.LoadUint64(&rh.dropped)}
+}
+
+// flush asks the sender goroutine to drain msgChan and send the pending
+// batch (if any), then waits for it to finish. It is safe to call
+// concurrently with logging and with Close: if Close has already stopped
+// the sender goroutine, flush returns nil instead of blocking on a
+// flushChan nobody reads anymore.
+func (rh *RocketHook) flush(ctx context.Context) error {
+	if !rh.isRunning() {
+		return nil
+	}
+
+	reply := make(chan error, 1)
+	select {
+	case rh.flushChan <- reply:
+	case <-rh.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-rh.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes any pending batch, respecting the rate limiter and retry
+// policy, then stops the sender goroutine. Call it before process exit so
+// attachments still sitting in msgChan aren't lost. Safe to call more than
+// once or concurrently; only the first call does the work and returns its
+// result, the rest wait for it to finish and return nil.
+func (rh *RocketHook) Close(ctx context.Context) error {
+	if !rh.isRunning() {
+		return nil
+	}
+
+	var err error
+	rh.closeOnce.Do(func() {
+		err = rh.flush(ctx)
+		close(rh.stopChan)
+
+		select {
+		case <-rh.stopped:
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+
+		atomic.StoreInt32(&rh.running, 0)
+	})
+	return err
 }
 
 // Fire -  Sent event to RocketChat
@@ -190,37 +473,17 @@ func (rh *RocketHook) GetHook() func(zapcore.Entry) error {
 		if rh.Disabled {
 			return nil
 		}
-		if !rh.running {
+		if !rh.isRunning() {
 			return NotRunningErr
 		}
 		if !rh.isAcceptedLevel(e.Level) {
 			return nil
 		}
 
-		color := ""
-		switch e.Level {
-		case zapcore.DebugLevel:
-			color = "purple"
-		case zapcore.InfoLevel:
-			color = "green"
-		case zapcore.ErrorLevel, zapcore.PanicLevel, zapcore.DPanicLevel, zapcore.FatalLevel:
-			color = "red"
-		default:
-			color = "yellow"
+		rh.enqueue(rh.Formatter.Format(e, nil))
+		if e.Level == zapcore.FatalLevel || e.Level == zapcore.PanicLevel {
+			return rh.flush(context.Background())
 		}
-
-		stack := ""
-		if len(e.Stack) > 0 {
-			stack = "\n\nStack:\n" + e.Stack
-		}
-		msg := &models.Attachment{
-			Color: color,
-			Title: e.Level.String() + " log",
-			Ts:    e.Time.String(),
-			Text:  e.Message + "\n\nCaller:\n" + e.Caller.String() + stack,
-		}
-
-		rh.msgChan <- msg
 		return nil
 	}
 }
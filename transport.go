@@ -0,0 +1,41 @@
+package rocketzap
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+// Transport delivers a batched PostMessage to RocketChat. RESTTransport and
+// WebhookTransport are the two built-in implementations; anything else
+// (a mock for tests, a Slack-compatible relay, ...) just needs to satisfy
+// this one method.
+type Transport interface {
+	Send(ctx context.Context, msg *models.PostMessage) error
+}
+
+// Reconnector is implemented by a Transport that can rebuild its
+// connection, e.g. after a session token expires or the sender goroutine
+// decides the connection is zombied. Transports with nothing to rebuild
+// (WebhookTransport) don't need to implement it.
+type Reconnector interface {
+	Reconnect() error
+}
+
+// Uploader is implemented by a Transport that can offload oversize
+// attachment text to a file and return a link to it. Transports that
+// can't (WebhookTransport has no auth token to upload with) don't need to
+// implement it, and oversize attachments are sent as-is.
+type Uploader interface {
+	UploadFile(filename, content string) (link string, err error)
+}
+
+// RateLimitHeaderer is implemented by a Transport that exposes the
+// response headers from its most recent Send response, win or lose, so
+// send1 can update the limiter proactively off X-RateLimit-* instead of
+// waiting for a 429 to learn the same thing. Returns nil if no Send has
+// received a response yet.
+type RateLimitHeaderer interface {
+	RateLimitHeader() http.Header
+}
@@ -0,0 +1,123 @@
+package rocketzap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+// maxInlineDefaultBytes is the default MaxInlineBytes threshold.
+const maxInlineDefaultBytes = 5000
+
+// inlineSummaryBytes is how much of an oversize attachment's Text is kept
+// inline, as a preview, once the rest has been uploaded as a file.
+const inlineSummaryBytes = 200
+
+// errNotConnected is returned by a Transport's UploadFile when it hasn't
+// connected (and so has no base URL or auth token) yet.
+var errNotConnected = errors.New("rocketzap: transport is not connected yet")
+
+// offloadOversizeAttachments uploads attachments as files, replacing each
+// with a short preview and a link, whenever an individual attachment's
+// Text exceeds MaxInlineBytes or the running total of the batch's
+// rendered Text does - so either one oversize stack trace, or several
+// individually-small attachments that together exceed RocketChat's
+// message-size cap, get offloaded instead of failing the whole batch
+// outright. It is a no-op if rh.Transport doesn't implement Uploader.
+func (rh *RocketHook) offloadOversizeAttachments() {
+	uploader, ok := rh.Transport.(Uploader)
+	if !ok {
+		return
+	}
+
+	limit := rh.MaxInlineBytes
+	if limit <= 0 {
+		limit = maxInlineDefaultBytes
+	}
+
+	var total int64
+	for i := range rh.msg.Attachments {
+		a := &rh.msg.Attachments[i]
+		oversize := int64(len(a.Text)) > limit
+		overBudget := total+int64(len(a.Text)) > limit
+		if oversize || overBudget {
+			offloadAttachment(uploader, a)
+		}
+		total += int64(len(a.Text))
+	}
+}
+
+func offloadAttachment(uploader Uploader, a *models.Attachment) {
+	summary := a.Text
+	if len(summary) > inlineSummaryBytes {
+		summary = summary[:inlineSummaryBytes] + "…"
+	}
+
+	filename := strings.ToLower(strings.ReplaceAll(a.Title, " ", "-")) + ".log.txt"
+	link, err := uploader.UploadFile(filename, a.Text)
+	if err != nil {
+		a.Text = fmt.Sprintf("%s\n\n(log too large to display inline, and upload failed: %s)", summary, err)
+		return
+	}
+	a.Text = fmt.Sprintf("%s\n\n(log too large to display inline; full output uploaded: %s)", summary, link)
+}
+
+// uploadFile uploads content to channel via RocketChat's room-upload
+// endpoint and returns a link to the stored file.
+func uploadFile(baseURL, channel, userID, token, filename, content string) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	uploadURL := fmt.Sprintf("%s/api/v1/rooms.upload/%s", baseURL, url.PathEscape(channel))
+	req, err := http.NewRequest(http.MethodPost, uploadURL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Auth-Token", token)
+	req.Header.Set("X-User-Id", userID)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("rocketzap: file upload failed with status %d", resp.StatusCode)
+	}
+
+	var parsed struct {
+		Message struct {
+			Attachments []struct {
+				TitleLink string `json:"title_link"`
+			} `json:"attachments"`
+		} `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Message.Attachments) == 0 || parsed.Message.Attachments[0].TitleLink == "" {
+		return "", fmt.Errorf("rocketzap: upload response missing file link")
+	}
+	return baseURL + parsed.Message.Attachments[0].TitleLink, nil
+}
@@ -0,0 +1,110 @@
+package rocketzap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RocketChat/Rocket.Chat.Go.SDK/models"
+)
+
+// Mode selects the delivery transport RocketHook builds by default when
+// Transport isn't set explicitly.
+type Mode int
+
+const (
+	// ModeAuto picks ModeWebhook or ModeREST based on the shape of HookURL.
+	ModeAuto Mode = iota
+	// ModeREST logs in as a bot user via the RocketChat REST API.
+	ModeREST
+	// ModeWebhook posts directly to a RocketChat Incoming Webhook URL
+	// (e.g. "https://host/hooks/<token>"), no login required.
+	ModeWebhook
+)
+
+// webhookHint is the URL path segment that marks a RocketChat Incoming
+// Webhook endpoint.
+const webhookHint = "/hooks/"
+
+// detectMode infers the delivery mode from the shape of hookURL.
+func detectMode(hookURL string) Mode {
+	if strings.Contains(hookURL, webhookHint) {
+		return ModeWebhook
+	}
+	return ModeREST
+}
+
+// WebhookTransport delivers messages by POSTing directly to a RocketChat
+// Incoming Webhook URL, bypassing login entirely. It is RocketHook's
+// default Transport whenever HookURL looks like a webhook URL.
+type WebhookTransport struct {
+	HookURL string
+
+	httpClient *http.Client
+	lastHeader http.Header
+}
+
+// Send implements Transport.
+func (t *WebhookTransport) Send(ctx context.Context, msg *models.PostMessage) error {
+	if t.httpClient == nil {
+		t.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	hookURL := t.HookURL
+	if !strings.Contains(hookURL, "://") {
+		hookURL = "http://" + hookURL
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	t.lastHeader = resp.Header
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &webhookError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	return nil
+}
+
+// RateLimitHeader implements RateLimitHeaderer, exposing the response
+// headers from the most recent webhook post, whether or not it succeeded,
+// so the limiter can update proactively rather than only reacting to a
+// 429.
+func (t *WebhookTransport) RateLimitHeader() http.Header {
+	return t.lastHeader
+}
+
+// webhookError reports a non-2xx response from a RocketChat Incoming
+// Webhook endpoint. Header is kept so callers can read rate-limit and
+// Retry-After information off it.
+type webhookError struct {
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *webhookError) Error() string {
+	return fmt.Sprintf("rocketzap: webhook request failed with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// httpStatusCode and httpHeader implement httpStatusError, so
+// applyRateLimitFeedback can react to this error the same way it does to
+// RESTTransport's restError.
+func (e *webhookError) httpStatusCode() int     { return e.StatusCode }
+func (e *webhookError) httpHeader() http.Header { return e.Header }